@@ -6,11 +6,15 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/javi11/altmount/internal/cache"
 	"github.com/javi11/altmount/internal/config"
+	"github.com/javi11/altmount/internal/dockerplugin"
 	"github.com/javi11/altmount/internal/rclone"
+	"github.com/javi11/altmount/internal/uploadqueue"
 	"github.com/javi11/altmount/pkg/rclonecli"
 )
 
@@ -18,22 +22,44 @@ import (
 type RCloneHandlers struct {
 	mountService *rclone.MountService
 	configGetter config.ConfigGetter
+	cacheManager *cache.Manager
+	dockerPlugin *dockerplugin.Server
 }
 
-// NewRCloneHandlers creates new RClone handlers
-func NewRCloneHandlers(mountService *rclone.MountService, configGetter config.ConfigGetter) *RCloneHandlers {
+// NewRCloneHandlers creates new RClone handlers. If cacheManager is nil, one
+// is built from the current config and started immediately, so callers
+// don't need to duplicate that wiring at every call site.
+func NewRCloneHandlers(mountService *rclone.MountService, configGetter config.ConfigGetter, cacheManager *cache.Manager) *RCloneHandlers {
+	if cacheManager == nil {
+		cacheManager = cache.NewManagerFromConfig(configGetter())
+		cacheManager.Start(context.Background())
+	}
+
 	return &RCloneHandlers{
 		mountService: mountService,
 		configGetter: configGetter,
+		cacheManager: cacheManager,
 	}
 }
 
-// GetMountStatus returns the current mount status
+// GetMountStatus returns the current mount status, including a summary of
+// pending offline uploads so the UI can warn before unmount.
 func (h *RCloneHandlers) GetMountStatus(c *fiber.Ctx) error {
 	status := h.mountService.GetStatus()
+
+	data := fiber.Map{"status": status}
+	if queue, ok := h.mountService.Uploads(); ok {
+		uploadStats, err := queue.Stats()
+		if err != nil {
+			slog.ErrorContext(c.Context(), "Failed to collect upload queue stats", "error", err)
+		} else {
+			data["pending_uploads"] = uploadStats
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    status,
+		"data":    data,
 	})
 }
 
@@ -53,8 +79,23 @@ func (h *RCloneHandlers) StartMount(c *fiber.Ctx) error {
 	})
 }
 
-// StopMount stops the rclone mount
+// StopMount stops the rclone mount. If offline uploading has a non-empty
+// backlog it refuses, unless the caller passes ?force=true, since stopping
+// the mount abandons any writes still staged locally.
 func (h *RCloneHandlers) StopMount(c *fiber.Ctx) error {
+	if !c.QueryBool("force", false) {
+		pending, err := h.mountService.PendingUploads()
+		if err != nil {
+			return RespondInternalError(c, "Failed to check pending uploads", err.Error())
+		}
+		if pending > 0 {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"message": fmt.Sprintf("%d upload(s) are still pending; pass ?force=true to unmount anyway", pending),
+			})
+		}
+	}
+
 	if err := h.mountService.Unmount(c.Context()); err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -68,7 +109,10 @@ func (h *RCloneHandlers) StopMount(c *fiber.Ctx) error {
 	})
 }
 
-// TestMountConfig tests the mount configuration
+// TestMountConfig tests the mount configuration. With the embedded backend
+// there is no external rclone remote to resolve ahead of time, so this only
+// validates the mount point; with the external backend it also requires an
+// RC connection to already be configured, mirroring TestRCloneConnection.
 func (h *RCloneHandlers) TestMountConfig(c *fiber.Ctx) error {
 	// Parse test configuration from request body
 	var testConfig struct {
@@ -95,14 +139,73 @@ func (h *RCloneHandlers) TestMountConfig(c *fiber.Ctx) error {
 		testCfg.RClone.MountOptions = testConfig.MountOptions
 	}
 
+	if testCfg.MountPath == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Mount point is required",
+		})
+	}
+
+	if testCfg.RClone.Backend != config.BackendEmbedded {
+		if testCfg.RClone.RCUrl == "" {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "External backend requires an RC URL to be configured",
+			})
+		}
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Mount configuration is valid",
 	})
 }
 
-// TestRCloneConnection tests the RClone RC connection
+// RefreshMountCache forgets cached directory listings under the "dir" query
+// param (or the mount root if omitted), forcing the next access to re-list
+// it from the remote. Only supported on the embedded backend.
+func (h *RCloneHandlers) RefreshMountCache(c *fiber.Ctx) error {
+	dir := c.Query("dir", "/")
+	if err := h.mountService.Refresh(c.Context(), dir); err != nil {
+		return RespondBadRequest(c, "Failed to refresh cache", err.Error())
+	}
+	return RespondSuccess(c, fiber.Map{"dir": dir})
+}
+
+// ForgetMountCache drops cached metadata under the "dir" query param (or
+// the mount root if omitted). Only supported on the embedded backend.
+func (h *RCloneHandlers) ForgetMountCache(c *fiber.Ctx) error {
+	dir := c.Query("dir", "/")
+	if err := h.mountService.Forget(c.Context(), dir); err != nil {
+		return RespondBadRequest(c, "Failed to forget cache", err.Error())
+	}
+	return RespondSuccess(c, fiber.Map{"dir": dir})
+}
+
+// InvalidateMountCache drops the whole directory cache. Only supported on
+// the embedded backend.
+func (h *RCloneHandlers) InvalidateMountCache(c *fiber.Ctx) error {
+	if err := h.mountService.Invalidate(c.Context(), "/"); err != nil {
+		return RespondBadRequest(c, "Failed to invalidate cache", err.Error())
+	}
+	return RespondSuccess(c, fiber.Map{"invalidated": true})
+}
+
+// TestRCloneConnection tests the RClone RC connection. With the embedded
+// backend there is no separate rclone process to reach, so this is a
+// no-op success.
 func (h *RCloneHandlers) TestRCloneConnection(c *fiber.Ctx) error {
+	if h.configGetter().RClone.Backend == config.BackendEmbedded {
+		return c.Status(200).JSON(fiber.Map{
+			"success": true,
+			"data": fiber.Map{
+				"success":       true,
+				"error_message": "",
+				"message":       "Embedded backend does not use an external RC server",
+			},
+		})
+	}
+
 	// Decode test request
 	var testReq struct {
 		RCUrl   string `json:"rc_url"`
@@ -154,28 +257,204 @@ func (h *RCloneHandlers) TestRCloneConnection(c *fiber.Ctx) error {
 	})
 }
 
-// ClearRCloneCache removes the rclone VFS cache directory and recreates it empty.
-func (h *RCloneHandlers) ClearRCloneCache(c *fiber.Ctx) error {
+// GetCacheStats returns per-cache stats (entry count, bytes, oldest entry,
+// last eviction) for every configured cache.
+func (h *RCloneHandlers) GetCacheStats(c *fiber.Ctx) error {
+	stats, err := h.cacheManager.Stats()
+	if err != nil {
+		return RespondInternalError(c, "Failed to collect cache stats", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{"caches": stats})
+}
+
+// ClearCache wholesale-clears a single named cache. Clearing the "vfs"
+// cache requires the mount to be stopped first, since removing files out
+// from under an active FUSE mount would corrupt open handles.
+func (h *RCloneHandlers) ClearCache(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	cch, ok := h.cacheManager.Get(name)
+	if !ok {
+		return RespondBadRequest(c, fmt.Sprintf("Unknown cache %q", name), "")
+	}
+
+	if name == "vfs" && h.mountService.GetStatus().Mounted {
+		return RespondBadRequest(c, "Stop the mount before clearing the vfs cache", "")
+	}
+
+	slog.InfoContext(c.Context(), "Clearing cache", "cache", name, "dir", cch.Dir())
+
+	if err := cch.Clear(); err != nil {
+		slog.ErrorContext(c.Context(), "Failed to clear cache", "cache", name, "error", err)
+		return RespondInternalError(c, "Failed to clear cache", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{"cache": name})
+}
+
+// EvictCache triggers an immediate janitor pass (age- and size-based
+// eviction) on a single named cache, without clearing it wholesale.
+func (h *RCloneHandlers) EvictCache(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	cch, ok := h.cacheManager.Get(name)
+	if !ok {
+		return RespondBadRequest(c, fmt.Sprintf("Unknown cache %q", name), "")
+	}
+
+	freed, err := cch.Sweep()
+	if err != nil {
+		return RespondInternalError(c, "Failed to evict cache", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{"cache": name, "bytes_freed": freed})
+}
+
+// EnableDockerPlugin starts the Docker Managed Volume Plugin socket,
+// allowing containers to request on-demand mounts of the altmount VFS.
+func (h *RCloneHandlers) EnableDockerPlugin(c *fiber.Ctx) error {
+	if h.dockerPlugin != nil && h.dockerPlugin.Running() {
+		return RespondSuccess(c, fiber.Map{"enabled": true})
+	}
+
 	cfg := h.configGetter()
-	cacheDir := cfg.RClone.CacheDir
-	if cacheDir == "" {
-		return RespondBadRequest(c, "Cache directory is not configured", "")
+	socketPath := cfg.DockerPlugin.SocketPath
+	if socketPath == "" {
+		socketPath = dockerplugin.DefaultSocketPath
+	}
+	stateDir := cfg.DockerPlugin.StateDir
+	if stateDir == "" {
+		stateDir = filepath.Join(cfg.DataDir, "docker-plugin")
+	}
+
+	h.dockerPlugin = dockerplugin.NewServer(socketPath, stateDir, h.mountService, h.configGetter)
+
+	if err := h.dockerPlugin.Start(c.Context()); err != nil {
+		slog.ErrorContext(c.Context(), "Failed to start Docker volume plugin", "error", err)
+		return RespondInternalError(c, "Failed to enable Docker volume plugin", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{"enabled": true, "socket": socketPath})
+}
+
+// DisableDockerPlugin stops the Docker Managed Volume Plugin socket.
+func (h *RCloneHandlers) DisableDockerPlugin(c *fiber.Ctx) error {
+	if h.dockerPlugin == nil || !h.dockerPlugin.Running() {
+		return RespondSuccess(c, fiber.Map{"enabled": false})
+	}
+
+	if err := h.dockerPlugin.Stop(c.Context()); err != nil {
+		return RespondInternalError(c, "Failed to disable Docker volume plugin", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{"enabled": false})
+}
+
+// ListDockerVolumes lists the Docker volumes currently registered with the
+// volume plugin, whether or not they are mounted right now.
+func (h *RCloneHandlers) ListDockerVolumes(c *fiber.Ctx) error {
+	if h.dockerPlugin == nil {
+		return RespondSuccess(c, fiber.Map{"volumes": []dockerplugin.VolumeInfo{}})
+	}
+
+	return RespondSuccess(c, fiber.Map{"volumes": h.dockerPlugin.Volumes()})
+}
+
+// uploadsOrNotEnabled returns the mount's offline upload queue, or writes a
+// 400 response and a nil queue if offline uploading isn't enabled.
+// Handlers must check for a nil return before using it.
+func (h *RCloneHandlers) uploadsOrNotEnabled(c *fiber.Ctx) *uploadqueue.Queue {
+	queue, ok := h.mountService.Uploads()
+	if !ok {
+		_ = RespondBadRequest(c, "Offline uploading is not enabled", "")
+		return nil
+	}
+	return queue
+}
+
+// ListUploads returns a paginated listing of the offline upload queue,
+// optionally filtered by state.
+func (h *RCloneHandlers) ListUploads(c *fiber.Ctx) error {
+	queue := h.uploadsOrNotEnabled(c)
+	if queue == nil {
+		return nil
+	}
+
+	filter := uploadqueue.ListFilter{
+		State:  uploadqueue.State(c.Query("state")),
+		Offset: c.QueryInt("offset", 0),
+		Limit:  c.QueryInt("limit", 50),
+	}
+
+	items, total, err := queue.List(filter)
+	if err != nil {
+		return RespondInternalError(c, "Failed to list uploads", err.Error())
+	}
+
+	return RespondSuccess(c, fiber.Map{
+		"uploads": items,
+		"total":   total,
+		"offset":  filter.Offset,
+		"limit":   filter.Limit,
+	})
+}
+
+// RetryUpload resets a failed upload back to pending for immediate
+// re-attempt.
+func (h *RCloneHandlers) RetryUpload(c *fiber.Ctx) error {
+	queue := h.uploadsOrNotEnabled(c)
+	if queue == nil {
+		return nil
+	}
+
+	item, err := queue.Retry(c.Params("id"))
+	if err != nil {
+		return RespondBadRequest(c, "Failed to retry upload", err.Error())
+	}
+
+	return RespondSuccess(c, item)
+}
+
+// DeleteUpload aborts a queued upload and removes its staged local copy.
+func (h *RCloneHandlers) DeleteUpload(c *fiber.Ctx) error {
+	queue := h.uploadsOrNotEnabled(c)
+	if queue == nil {
+		return nil
 	}
 
-	slog.InfoContext(c.Context(), "Clearing rclone cache directory", "cache_dir", cacheDir)
+	id := c.Params("id")
+
+	item, err := queue.Get(id)
+	if err != nil {
+		return RespondBadRequest(c, "Failed to abort upload", err.Error())
+	}
 
-	if err := os.RemoveAll(cacheDir); err != nil {
-		slog.ErrorContext(c.Context(), "Failed to remove rclone cache directory", "cache_dir", cacheDir, "error", err)
-		return RespondInternalError(c, "Failed to clear rclone cache", err.Error())
+	if err := os.Remove(item.LocalPath); err != nil && !os.IsNotExist(err) {
+		return RespondInternalError(c, "Failed to remove staged file", err.Error())
 	}
 
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		slog.ErrorContext(c.Context(), "Failed to recreate rclone cache directory", "cache_dir", cacheDir, "error", err)
-		return RespondInternalError(c, "Failed to recreate cache directory", err.Error())
+	if err := queue.Delete(id); err != nil {
+		return RespondInternalError(c, "Failed to remove upload from queue", err.Error())
 	}
 
-	slog.InfoContext(c.Context(), "Rclone cache directory cleared", "cache_dir", cacheDir)
-	return RespondSuccess(c, fiber.Map{"cache_dir": cacheDir})
+	return RespondSuccess(c, fiber.Map{"id": id})
+}
+
+// GetUploadStats returns queue-wide stats: pending count, bytes queued and
+// the age of the oldest pending upload.
+func (h *RCloneHandlers) GetUploadStats(c *fiber.Ctx) error {
+	queue := h.uploadsOrNotEnabled(c)
+	if queue == nil {
+		return nil
+	}
+
+	stats, err := queue.Stats()
+	if err != nil {
+		return RespondInternalError(c, "Failed to collect upload stats", err.Error())
+	}
+
+	return RespondSuccess(c, stats)
 }
 
 // RegisterRCloneRoutes registers RClone-related routes
@@ -186,7 +465,21 @@ func RegisterRCloneRoutes(apiGroup fiber.Router, handlers *RCloneHandlers) {
 	rcloneGroup.Post("/test", handlers.TestRCloneConnection)
 
 	// Cache management
-	rcloneGroup.Delete("/cache", handlers.ClearRCloneCache)
+	rcloneGroup.Get("/cache", handlers.GetCacheStats)
+	rcloneGroup.Delete("/cache/:name", handlers.ClearCache)
+	rcloneGroup.Post("/cache/:name/evict", handlers.EvictCache)
+
+	// Docker volume plugin management
+	dockerGroup := rcloneGroup.Group("/docker-plugin")
+	dockerGroup.Post("/enable", handlers.EnableDockerPlugin)
+	dockerGroup.Post("/disable", handlers.DisableDockerPlugin)
+	dockerGroup.Get("/volumes", handlers.ListDockerVolumes)
+
+	// Offline upload queue management
+	rcloneGroup.Get("/uploads", handlers.ListUploads)
+	rcloneGroup.Get("/uploads/stats", handlers.GetUploadStats)
+	rcloneGroup.Post("/uploads/:id/retry", handlers.RetryUpload)
+	rcloneGroup.Delete("/uploads/:id", handlers.DeleteUpload)
 
 	// Mount management
 	mountGroup := rcloneGroup.Group("/mount")
@@ -195,4 +488,7 @@ func RegisterRCloneRoutes(apiGroup fiber.Router, handlers *RCloneHandlers) {
 	mountGroup.Post("/stop", handlers.StopMount)
 	mountGroup.Delete("/", handlers.StopMount) // Alias for stop
 	mountGroup.Post("/test", handlers.TestMountConfig)
+	mountGroup.Post("/refresh", handlers.RefreshMountCache)
+	mountGroup.Post("/forget", handlers.ForgetMountCache)
+	mountGroup.Post("/invalidate", handlers.InvalidateMountCache)
 }
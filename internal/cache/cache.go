@@ -0,0 +1,180 @@
+// Package cache manages altmount's on-disk caches (VFS chunks, metadata,
+// downloaded NZBs, par2 blocks, thumbnails, ...) under a single, age- and
+// size-bounded janitor instead of the previous approach of nuking one
+// hardcoded directory on demand.
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// NoExpiry disables age-based eviction for a cache; entries are only
+// removed by the size-based LRU pass.
+const NoExpiry = -1 * time.Second
+
+// Config describes a single named cache.
+type Config struct {
+	// Dir is the on-disk directory this cache owns. It is walked and swept
+	// in full by the janitor, so it must not be shared between caches.
+	Dir string
+	// MaxAge is how long an entry may go unmodified before it is evicted.
+	// NoExpiry (-1) disables age-based eviction.
+	MaxAge time.Duration
+	// MaxSize is the maximum total size in bytes this cache may occupy.
+	// Zero disables size-based eviction.
+	MaxSize int64
+}
+
+// Stats summarizes the current state of a cache.
+type Stats struct {
+	Name         string    `json:"name"`
+	Dir          string    `json:"dir"`
+	EntryCount   int       `json:"entry_count"`
+	TotalBytes   int64     `json:"total_bytes"`
+	OldestEntry  time.Time `json:"oldest_entry,omitempty"`
+	LastEviction time.Time `json:"last_eviction,omitempty"`
+}
+
+// Cache is a single named, age- and size-bounded on-disk cache.
+type Cache struct {
+	name string
+	cfg  Config
+
+	lastEviction time.Time
+}
+
+func newCache(name string, cfg Config) *Cache {
+	return &Cache{name: name, cfg: cfg}
+}
+
+// Name returns the cache's configured name (e.g. "vfs", "metadata").
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// Dir returns the cache's on-disk directory.
+func (c *Cache) Dir() string {
+	return c.cfg.Dir
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Stats walks the cache directory and reports its current size and age
+// profile without evicting anything.
+func (c *Cache) Stats() (Stats, error) {
+	entries, err := c.list()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Name: c.name, Dir: c.cfg.Dir, LastEviction: c.lastEviction}
+	for _, e := range entries {
+		stats.EntryCount++
+		stats.TotalBytes += e.size
+		if stats.OldestEntry.IsZero() || e.modTime.Before(stats.OldestEntry) {
+			stats.OldestEntry = e.modTime
+		}
+	}
+	return stats, nil
+}
+
+// Sweep evicts entries older than MaxAge, then LRU-evicts remaining entries
+// until the cache is back under MaxSize. It reports how many bytes were
+// freed.
+func (c *Cache) Sweep() (int64, error) {
+	entries, err := c.list()
+	if err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	kept := entries[:0]
+	now := time.Now()
+
+	if c.cfg.MaxAge != NoExpiry && c.cfg.MaxAge > 0 {
+		for _, e := range entries {
+			if now.Sub(e.modTime) > c.cfg.MaxAge {
+				if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+					return freed, fmt.Errorf("evicting %s: %w", e.path, err)
+				}
+				freed += e.size
+				continue
+			}
+			kept = append(kept, e)
+		}
+		entries = kept
+	}
+
+	if c.cfg.MaxSize > 0 {
+		var total int64
+		for _, e := range entries {
+			total += e.size
+		}
+
+		if total > c.cfg.MaxSize {
+			sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+			for _, e := range entries {
+				if total <= c.cfg.MaxSize {
+					break
+				}
+				if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+					return freed, fmt.Errorf("evicting %s: %w", e.path, err)
+				}
+				freed += e.size
+				total -= e.size
+			}
+		}
+	}
+
+	if freed > 0 {
+		c.lastEviction = now
+	}
+	return freed, nil
+}
+
+// Clear removes and recreates the cache directory from scratch.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.cfg.Dir); err != nil {
+		return fmt.Errorf("removing cache dir %s: %w", c.cfg.Dir, err)
+	}
+	if err := os.MkdirAll(c.cfg.Dir, 0755); err != nil {
+		return fmt.Errorf("recreating cache dir %s: %w", c.cfg.Dir, err)
+	}
+	c.lastEviction = time.Now()
+	return nil
+}
+
+func (c *Cache) list() ([]entry, error) {
+	var entries []entry
+
+	err := filepath.WalkDir(c.cfg.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking cache dir %s: %w", c.cfg.Dir, err)
+	}
+
+	return entries, nil
+}
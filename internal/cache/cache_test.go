@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, dir, name string, size int, age time.Duration) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("setting mtime on %s: %v", path, err)
+	}
+	return path
+}
+
+func TestCacheSweepEvictsByAge(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "old", 10, time.Hour)
+	writeTestFile(t, dir, "new", 10, time.Second)
+
+	c := newCache("test", Config{Dir: dir, MaxAge: time.Minute})
+
+	freed, err := c.Sweep()
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if freed != 10 {
+		t.Fatalf("freed = %d, want 10", freed)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Fatalf("expected old entry to be evicted, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); err != nil {
+		t.Fatalf("expected new entry to survive: %v", err)
+	}
+}
+
+func TestCacheSweepEvictsBySizeLRU(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "oldest", 100, 3*time.Hour)
+	writeTestFile(t, dir, "middle", 100, 2*time.Hour)
+	writeTestFile(t, dir, "newest", 100, time.Hour)
+
+	c := newCache("test", Config{Dir: dir, MaxAge: NoExpiry, MaxSize: 150})
+
+	if _, err := c.Sweep(); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "oldest")); !os.IsNotExist(err) {
+		t.Fatalf("expected oldest entry to be LRU-evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "newest")); err != nil {
+		t.Fatalf("expected newest entry to survive: %v", err)
+	}
+
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.TotalBytes > 150 {
+		t.Fatalf("total bytes %d still over MaxSize 150", stats.TotalBytes)
+	}
+}
+
+func TestCacheClearRecreatesEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "anything", 5, 0)
+
+	c := newCache("test", Config{Dir: dir})
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty dir after Clear, got %d entries", len(entries))
+	}
+}
+
+func TestManagerStatsAcrossCaches(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	writeTestFile(t, dirA, "a", 20, 0)
+	writeTestFile(t, dirB, "b", 30, 0)
+
+	m := NewManager(map[string]Config{
+		"vfs":      {Dir: dirA, MaxAge: NoExpiry},
+		"metadata": {Dir: dirB, MaxAge: NoExpiry},
+	})
+
+	stats, err := m.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats["vfs"].TotalBytes != 20 {
+		t.Fatalf("vfs bytes = %d, want 20", stats["vfs"].TotalBytes)
+	}
+	if stats["metadata"].TotalBytes != 30 {
+		t.Fatalf("metadata bytes = %d, want 30", stats["metadata"].TotalBytes)
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	got := ExpandPlaceholders(":cacheDir/thumbnails", "/var/cache/altmount", "/var/lib/altmount")
+	want := "/var/cache/altmount/thumbnails"
+	if got != want {
+		t.Fatalf("ExpandPlaceholders = %q, want %q", got, want)
+	}
+
+	got = ExpandPlaceholders(":dataDir/nzb", "/var/cache/altmount", "/var/lib/altmount")
+	want = "/var/lib/altmount/nzb"
+	if got != want {
+		t.Fatalf("ExpandPlaceholders = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,19 @@
+package cache
+
+import "github.com/javi11/altmount/internal/config"
+
+// NewManagerFromConfig builds a Manager from the user-configured caches in
+// cfg.Cache, expanding the ":cacheDir"/":dataDir" placeholders against
+// cfg.RClone.CacheDir and cfg.DataDir.
+func NewManagerFromConfig(cfg *config.Config) *Manager {
+	configs := make(map[string]Config, len(cfg.Cache))
+	for name, entry := range cfg.Cache {
+		configs[name] = Config{
+			Dir:     ExpandPlaceholders(entry.Dir, cfg.RClone.CacheDir, cfg.DataDir),
+			MaxAge:  entry.MaxAge,
+			MaxSize: entry.MaxSize,
+		}
+	}
+
+	return NewManager(configs)
+}
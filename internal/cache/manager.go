@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJanitorInterval is how often the Manager sweeps every configured
+// cache; NewManager always uses it.
+const DefaultJanitorInterval = 15 * time.Minute
+
+// Manager owns a set of named caches and periodically sweeps all of them.
+type Manager struct {
+	janitorInterval time.Duration
+
+	mu     sync.Mutex
+	caches map[string]*Cache
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ExpandPlaceholders substitutes the portable ":cacheDir" and ":dataDir"
+// placeholders used in cache directory settings with the actual configured
+// paths, so per-cache config can stay host-independent.
+func ExpandPlaceholders(dir, cacheDir, dataDir string) string {
+	dir = strings.ReplaceAll(dir, ":cacheDir", cacheDir)
+	dir = strings.ReplaceAll(dir, ":dataDir", dataDir)
+	return dir
+}
+
+// NewManager creates a cache manager for the given named cache configs.
+func NewManager(configs map[string]Config) *Manager {
+	m := &Manager{
+		janitorInterval: DefaultJanitorInterval,
+		caches:          make(map[string]*Cache, len(configs)),
+	}
+	for name, cfg := range configs {
+		m.caches[name] = newCache(name, cfg)
+	}
+	return m
+}
+
+// Start begins the periodic janitor. It is safe to call Stop even if Start
+// was never called.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	m.mu.Unlock()
+
+	go m.run(ctx)
+}
+
+// Stop halts the periodic janitor and waits for the current sweep, if any,
+// to finish.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	done := m.done
+	m.stop = nil
+	m.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (m *Manager) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.SweepAll(ctx)
+		}
+	}
+}
+
+// SweepAll runs a janitor pass over every configured cache, logging but not
+// aborting on a per-cache failure.
+func (m *Manager) SweepAll(ctx context.Context) {
+	for _, name := range m.Names() {
+		c, ok := m.Get(name)
+		if !ok {
+			continue
+		}
+		freed, err := c.Sweep()
+		if err != nil {
+			slog.ErrorContext(ctx, "Cache sweep failed", "cache", name, "error", err)
+			continue
+		}
+		if freed > 0 {
+			slog.InfoContext(ctx, "Cache sweep evicted entries", "cache", name, "bytes_freed", freed)
+		}
+	}
+}
+
+// Get returns the named cache, if configured.
+func (m *Manager) Get(name string) (*Cache, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.caches[name]
+	return c, ok
+}
+
+// Names returns the configured cache names.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Stats returns per-cache stats for every configured cache.
+func (m *Manager) Stats() (map[string]Stats, error) {
+	stats := make(map[string]Stats, len(m.caches))
+	for _, name := range m.Names() {
+		c, ok := m.Get(name)
+		if !ok {
+			continue
+		}
+		s, err := c.Stats()
+		if err != nil {
+			return nil, fmt.Errorf("stats for cache %q: %w", name, err)
+		}
+		stats[name] = s
+	}
+	return stats, nil
+}
@@ -0,0 +1,113 @@
+// Package config holds altmount's runtime configuration and the accessor
+// used to read it from other packages without introducing an import cycle
+// back to the config loader/store.
+package config
+
+import "time"
+
+// Backend selects how the mount subsystem talks to the underlying rclone
+// filesystem.
+type Backend string
+
+const (
+	// BackendExternal drives a separate rclone process over its RC HTTP
+	// API. This is the historical, default behaviour.
+	BackendExternal Backend = "external"
+	// BackendEmbedded constructs the VFS in-process using the rclone
+	// libraries directly, with no separate rclone binary involved.
+	BackendEmbedded Backend = "embedded"
+)
+
+// RCloneConfig configures the mount's connection to rclone, whichever
+// backend is in use.
+type RCloneConfig struct {
+	Backend Backend `yaml:"backend" json:"backend"`
+
+	// Remote is the rclone remote spec (e.g. "myremote:path") backing the
+	// mount. Used by both backends to resolve the fs.Fs to mount.
+	Remote string `yaml:"remote" json:"remote"`
+
+	// RCUrl/RCUser/RCPass/VFSName address an external rclone RC server.
+	// Unused when Backend is BackendEmbedded.
+	RCUrl   string `yaml:"rc_url" json:"rc_url"`
+	RCUser  string `yaml:"rc_user" json:"rc_user"`
+	RCPass  string `yaml:"rc_pass" json:"rc_pass"`
+	VFSName string `yaml:"vfs_name" json:"vfs_name"`
+
+	CacheDir     string            `yaml:"cache_dir" json:"cache_dir"`
+	MountOptions map[string]string `yaml:"mount_options" json:"mount_options"`
+}
+
+// DockerPluginConfig configures the optional Docker Managed Volume Plugin.
+type DockerPluginConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// SocketPath defaults to dockerplugin.DefaultSocketPath when empty.
+	SocketPath string `yaml:"socket_path" json:"socket_path"`
+	// StateDir holds the persisted volume registry; defaults to a
+	// "docker-plugin" directory under DataDir when empty.
+	StateDir string `yaml:"state_dir" json:"state_dir"`
+}
+
+// CacheConfig describes a single named cache managed by internal/cache.
+// Dir may reference the ":cacheDir" and ":dataDir" placeholders, expanded
+// against Config.RClone.CacheDir and Config.DataDir respectively.
+type CacheConfig struct {
+	Dir string `yaml:"dir" json:"dir"`
+	// MaxAge is how long an entry may go unmodified before eviction.
+	// A negative value disables age-based eviction.
+	MaxAge time.Duration `yaml:"max_age" json:"max_age"`
+	// MaxSize is the maximum total size in bytes; zero disables
+	// size-based eviction.
+	MaxSize int64 `yaml:"max_size" json:"max_size"`
+}
+
+// UploadQueueConfig configures the offline write-back upload queue.
+type UploadQueueConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// DBPath overrides where the persistent queue database is stored;
+	// defaults to "uploads.db" under RClone.CacheDir when empty.
+	DBPath string `yaml:"db_path" json:"db_path"`
+	// StagingDir overrides where writes are staged before upload;
+	// defaults to "uploads" under DataDir when empty.
+	StagingDir string `yaml:"staging_dir" json:"staging_dir"`
+	// Workers is how many uploads may be in flight at once. Defaults to 1
+	// when zero.
+	Workers int `yaml:"workers" json:"workers"`
+}
+
+// Config is altmount's top-level runtime configuration.
+type Config struct {
+	MountPath string `yaml:"mount_path" json:"mount_path"`
+	DataDir   string `yaml:"data_dir" json:"data_dir"`
+
+	RClone       RCloneConfig           `yaml:"rclone" json:"rclone"`
+	DockerPlugin DockerPluginConfig     `yaml:"docker_plugin" json:"docker_plugin"`
+	Cache        map[string]CacheConfig `yaml:"cache" json:"cache"`
+	UploadQueue  UploadQueueConfig      `yaml:"upload_queue" json:"upload_queue"`
+}
+
+// ConfigGetter returns the current configuration. Implementations must be
+// safe for concurrent use and return a value reflecting the latest reload.
+type ConfigGetter func() *Config
+
+// DeepCopy returns an independent copy of c, safe to mutate without
+// affecting the live configuration.
+func (c *Config) DeepCopy() *Config {
+	if c == nil {
+		return nil
+	}
+
+	cp := *c
+
+	cp.RClone.MountOptions = make(map[string]string, len(c.RClone.MountOptions))
+	for k, v := range c.RClone.MountOptions {
+		cp.RClone.MountOptions[k] = v
+	}
+
+	cp.Cache = make(map[string]CacheConfig, len(c.Cache))
+	for k, v := range c.Cache {
+		cp.Cache[k] = v
+	}
+
+	return &cp
+}
@@ -0,0 +1,247 @@
+// Package dockerplugin implements a Docker Managed Volume Plugin that
+// exposes altmount's mounted virtual filesystem to containers on demand.
+//
+// It speaks the plugin protocol documented at
+// https://docs.docker.com/engine/extend/plugins_volume/ over a Unix socket,
+// translating VolumeDriver.* requests into subpaths of the single FUSE mount
+// managed by rclone.MountService.
+package dockerplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/javi11/altmount/internal/config"
+)
+
+// DefaultSocketPath is the socket path Docker expects for a plugin named
+// "altmount" when no plugin spec file overrides it.
+const DefaultSocketPath = "/run/docker/plugins/altmount.sock"
+
+// pluginContentType is mandated by the Docker volume plugin protocol; every
+// response, including errors, must be served with this content type.
+const pluginContentType = "application/vnd.docker.plugins.v1.1+json"
+
+// MountController is the subset of rclone.MountService the plugin needs to
+// attach/detach the shared FUSE mount. It exists so the protocol handlers can
+// be unit-tested without a real rclone remote.
+type MountController interface {
+	Mount(ctx context.Context) error
+	Unmount(ctx context.Context) error
+}
+
+// Server is a Docker volume plugin backed by altmount's mount service. A
+// single instance owns one Unix socket and one on-disk volume registry.
+type Server struct {
+	socketPath   string
+	stateFile    string
+	mountService MountController
+	configGetter config.ConfigGetter
+
+	mu         sync.Mutex
+	volumes    map[string]*volume
+	mountCount int // total containers currently holding any volume
+
+	listener net.Listener
+	httpSrv  *http.Server
+}
+
+// volume tracks a Docker-created volume and which containers currently have
+// it mounted. Mounts is runtime-only state and is not persisted; Docker
+// re-issues Mount calls for anything that needs to be attached again after a
+// restart.
+type volume struct {
+	Name    string              `json:"name"`
+	Subpath string              `json:"subpath"`
+	Opts    map[string]string   `json:"opts,omitempty"`
+	Mounts  map[string]struct{} `json:"-"`
+}
+
+// volumeRecord is the on-disk representation of a volume.
+type volumeRecord struct {
+	Name    string            `json:"name"`
+	Subpath string            `json:"subpath"`
+	Opts    map[string]string `json:"opts,omitempty"`
+}
+
+// NewServer creates a Docker volume plugin server. stateDir is the
+// directory the volume registry is persisted under; socketPath may be empty
+// to use DefaultSocketPath.
+func NewServer(socketPath string, stateDir string, mountService MountController, configGetter config.ConfigGetter) *Server {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+
+	return &Server{
+		socketPath:   socketPath,
+		stateFile:    filepath.Join(stateDir, "docker-volumes.json"),
+		mountService: mountService,
+		configGetter: configGetter,
+		volumes:      make(map[string]*volume),
+	}
+}
+
+// Start loads persisted volume state, binds the plugin Unix socket and
+// begins serving the VolumeDriver protocol in the background.
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return fmt.Errorf("docker volume plugin already started")
+	}
+
+	if err := s.loadState(); err != nil {
+		return fmt.Errorf("loading docker volume plugin state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("creating plugin socket directory: %w", err)
+	}
+
+	_ = os.Remove(s.socketPath)
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.socketPath, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Plugin.Activate", s.handleActivate)
+	mux.HandleFunc("/VolumeDriver.Create", s.handleCreate)
+	mux.HandleFunc("/VolumeDriver.Remove", s.handleRemove)
+	mux.HandleFunc("/VolumeDriver.List", s.handleList)
+	mux.HandleFunc("/VolumeDriver.Get", s.handleGet)
+	mux.HandleFunc("/VolumeDriver.Path", s.handlePath)
+	mux.HandleFunc("/VolumeDriver.Mount", s.handleMount)
+	mux.HandleFunc("/VolumeDriver.Unmount", s.handleUnmount)
+	mux.HandleFunc("/VolumeDriver.Capabilities", s.handleCapabilities)
+
+	s.listener = listener
+	s.httpSrv = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.httpSrv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.ErrorContext(ctx, "Docker volume plugin server stopped unexpectedly", "error", err)
+		}
+	}()
+
+	slog.InfoContext(ctx, "Docker volume plugin listening", "socket", s.socketPath)
+	return nil
+}
+
+// Stop shuts down the plugin socket. Volumes that are still mounted are left
+// as-is; they will need to be re-mounted once the plugin starts again.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.httpSrv
+	socketPath := s.socketPath
+	s.httpSrv = nil
+	s.listener = nil
+	s.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutting down docker volume plugin: %w", err)
+	}
+
+	_ = os.Remove(socketPath)
+	slog.InfoContext(ctx, "Docker volume plugin stopped", "socket", socketPath)
+	return nil
+}
+
+// Running reports whether the plugin socket is currently active.
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listener != nil
+}
+
+// Volumes returns a snapshot of every registered volume, for the REST
+// listing endpoint.
+func (s *Server) Volumes() []VolumeInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]VolumeInfo, 0, len(s.volumes))
+	for _, v := range s.volumes {
+		out = append(out, VolumeInfo{
+			Name:         v.Name,
+			Subpath:      v.Subpath,
+			Opts:         v.Opts,
+			Mountpoint:   s.mountpointFor(v),
+			ActiveMounts: len(v.Mounts),
+		})
+	}
+	return out
+}
+
+// VolumeInfo is the REST-facing representation of a registered volume.
+type VolumeInfo struct {
+	Name         string            `json:"name"`
+	Subpath      string            `json:"subpath"`
+	Opts         map[string]string `json:"opts,omitempty"`
+	Mountpoint   string            `json:"mountpoint"`
+	ActiveMounts int               `json:"active_mounts"`
+}
+
+func (s *Server) loadState() error {
+	data, err := os.ReadFile(s.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []volumeRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("parsing %s: %w", s.stateFile, err)
+	}
+
+	for _, r := range records {
+		s.volumes[r.Name] = &volume{
+			Name:    r.Name,
+			Subpath: r.Subpath,
+			Opts:    r.Opts,
+			Mounts:  make(map[string]struct{}),
+		}
+	}
+	return nil
+}
+
+// saveState persists the volume registry. Callers must hold s.mu.
+func (s *Server) saveState() error {
+	records := make([]volumeRecord, 0, len(s.volumes))
+	for _, v := range s.volumes {
+		records = append(records, volumeRecord{Name: v.Name, Subpath: v.Subpath, Opts: v.Opts})
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.stateFile), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.stateFile, data, 0644)
+}
+
+// mountpointFor computes the absolute path a volume resolves to, relative
+// to the shared altmount FUSE mount. Callers must hold s.mu.
+func (s *Server) mountpointFor(v *volume) string {
+	cfg := s.configGetter()
+	return filepath.Join(cfg.MountPath, v.Subpath)
+}
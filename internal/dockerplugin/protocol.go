@@ -0,0 +1,307 @@
+package dockerplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// The request/response shapes below mirror the Docker volume plugin
+// protocol verbatim; field names and casing are dictated by the spec, not
+// by our own conventions.
+
+type activateResponse struct {
+	Implements []string `json:"Implements"`
+}
+
+type createRequest struct {
+	Name string            `json:"Name"`
+	Opts map[string]string `json:"Opts"`
+}
+
+type removeRequest struct {
+	Name string `json:"Name"`
+}
+
+type getRequest struct {
+	Name string `json:"Name"`
+}
+
+type pathRequest struct {
+	Name string `json:"Name"`
+}
+
+type mountRequest struct {
+	Name string `json:"Name"`
+	ID   string `json:"ID"`
+}
+
+type unmountRequest struct {
+	Name string `json:"Name"`
+	ID   string `json:"ID"`
+}
+
+type volumeResponse struct {
+	Name       string            `json:"Name"`
+	Mountpoint string            `json:"Mountpoint,omitempty"`
+	Status     map[string]string `json:"Status,omitempty"`
+}
+
+type getResponse struct {
+	Volume volumeResponse `json:"Volume"`
+}
+
+type listResponse struct {
+	Volumes []volumeResponse `json:"Volumes"`
+}
+
+type pathOrMountResponse struct {
+	Mountpoint string `json:"Mountpoint"`
+}
+
+type capabilitiesResponse struct {
+	Capabilities struct {
+		Scope string `json:"Scope"`
+	} `json:"Capabilities"`
+}
+
+type errResponse struct {
+	Err string `json:"Err"`
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", pluginContentType)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeErr reports a plugin-level failure. Per the protocol this is still a
+// 200 response with a populated "Err" field, not an HTTP error status.
+func writeErr(w http.ResponseWriter, err error) {
+	writeJSON(w, errResponse{Err: err.Error()})
+}
+
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, activateResponse{Implements: []string{"VolumeDriver"}})
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding create request: %w", err))
+		return
+	}
+	if req.Name == "" {
+		writeErr(w, fmt.Errorf("volume name is required"))
+		return
+	}
+	for key := range req.Opts {
+		if key != "path" {
+			writeErr(w, fmt.Errorf("unsupported volume option %q: only \"path\" is currently supported", key))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.volumes[req.Name]; ok {
+		// Docker re-issues Create for volumes it already knows about; treat
+		// it as a no-op rather than an error as long as nothing changed.
+		if existing.Subpath != req.Opts["path"] {
+			writeErr(w, fmt.Errorf("volume %q already exists with a different path", req.Name))
+			return
+		}
+		writeJSON(w, struct{}{})
+		return
+	}
+
+	s.volumes[req.Name] = &volume{
+		Name:    req.Name,
+		Subpath: req.Opts["path"],
+		Opts:    req.Opts,
+		Mounts:  make(map[string]struct{}),
+	}
+
+	if err := s.saveState(); err != nil {
+		delete(s.volumes, req.Name)
+		writeErr(w, fmt.Errorf("persisting volume: %w", err))
+		return
+	}
+
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request) {
+	var req removeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding remove request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.volumes[req.Name]
+	if !ok {
+		writeErr(w, fmt.Errorf("volume %q not found", req.Name))
+		return
+	}
+	if len(v.Mounts) > 0 {
+		writeErr(w, fmt.Errorf("volume %q is still mounted by %d container(s)", req.Name, len(v.Mounts)))
+		return
+	}
+
+	delete(s.volumes, req.Name)
+	if err := s.saveState(); err != nil {
+		writeErr(w, fmt.Errorf("persisting volume removal: %w", err))
+		return
+	}
+
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	var req getRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding get request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.volumes[req.Name]
+	if !ok {
+		writeErr(w, fmt.Errorf("volume %q not found", req.Name))
+		return
+	}
+
+	writeJSON(w, getResponse{Volume: s.toVolumeResponse(v)})
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := listResponse{Volumes: make([]volumeResponse, 0, len(s.volumes))}
+	for _, v := range s.volumes {
+		resp.Volumes = append(resp.Volumes, s.toVolumeResponse(v))
+	}
+
+	writeJSON(w, resp)
+}
+
+func (s *Server) handlePath(w http.ResponseWriter, r *http.Request) {
+	var req pathRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding path request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.volumes[req.Name]
+	if !ok {
+		writeErr(w, fmt.Errorf("volume %q not found", req.Name))
+		return
+	}
+	if len(v.Mounts) == 0 {
+		writeJSON(w, pathOrMountResponse{})
+		return
+	}
+
+	writeJSON(w, pathOrMountResponse{Mountpoint: s.mountpointFor(v)})
+}
+
+func (s *Server) handleMount(w http.ResponseWriter, r *http.Request) {
+	var req mountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding mount request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.volumes[req.Name]
+	if !ok {
+		writeErr(w, fmt.Errorf("volume %q not found", req.Name))
+		return
+	}
+
+	if _, held := v.Mounts[req.ID]; held {
+		// Docker is allowed to retry Mount for a container ID it already
+		// holds; treat it as a no-op instead of double-counting it, which
+		// would leak the shared mount by requiring two Unmounts to release
+		// what one Mount acquired.
+		writeJSON(w, pathOrMountResponse{Mountpoint: s.mountpointFor(v)})
+		return
+	}
+
+	if s.mountCount == 0 {
+		if err := s.mountService.Mount(r.Context()); err != nil {
+			writeErr(w, fmt.Errorf("starting altmount mount: %w", err))
+			return
+		}
+	}
+
+	v.Mounts[req.ID] = struct{}{}
+	s.mountCount++
+
+	writeJSON(w, pathOrMountResponse{Mountpoint: s.mountpointFor(v)})
+}
+
+func (s *Server) handleUnmount(w http.ResponseWriter, r *http.Request) {
+	var req unmountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, fmt.Errorf("decoding unmount request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.volumes[req.Name]
+	if !ok {
+		writeErr(w, fmt.Errorf("volume %q not found", req.Name))
+		return
+	}
+	if _, held := v.Mounts[req.ID]; !held {
+		// Already released, or never mounted by this container; Docker
+		// tolerates this as a no-op.
+		writeJSON(w, struct{}{})
+		return
+	}
+
+	// Only drop our bookkeeping once the real unmount has succeeded (or
+	// wasn't needed); otherwise a failed Unmount would leave the refcount
+	// saying this volume is released while the FUSE mount is still up.
+	if s.mountCount == 1 {
+		if err := s.mountService.Unmount(r.Context()); err != nil {
+			writeErr(w, fmt.Errorf("stopping altmount mount: %w", err))
+			return
+		}
+	}
+
+	delete(v.Mounts, req.ID)
+	s.mountCount--
+
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	var resp capabilitiesResponse
+	resp.Capabilities.Scope = "local"
+	writeJSON(w, resp)
+}
+
+// toVolumeResponse converts internal volume state to the wire format.
+// Callers must hold s.mu.
+func (s *Server) toVolumeResponse(v *volume) volumeResponse {
+	resp := volumeResponse{Name: v.Name}
+	if len(v.Mounts) > 0 {
+		resp.Mountpoint = s.mountpointFor(v)
+	}
+	return resp
+}
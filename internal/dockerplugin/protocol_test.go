@@ -0,0 +1,196 @@
+package dockerplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/javi11/altmount/internal/config"
+)
+
+// fakeMountController is a MountController test double that counts
+// Mount/Unmount calls and can be made to fail on demand.
+type fakeMountController struct {
+	mu           sync.Mutex
+	mountCalls   int
+	unmountErr   error
+	unmountCalls int
+}
+
+func (f *fakeMountController) Mount(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mountCalls++
+	return nil
+}
+
+func (f *fakeMountController) Unmount(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unmountCalls++
+	return f.unmountErr
+}
+
+func newTestServer(mount *fakeMountController) *Server {
+	return &Server{
+		mountService: mount,
+		configGetter: func() *config.Config { return &config.Config{MountPath: "/mnt/altmount"} },
+		volumes:      make(map[string]*volume),
+	}
+}
+
+// call drives a protocol handler through a real http.Request/ResponseWriter
+// pair and decodes the JSON response into T.
+func call[T any](t *testing.T, handler func(http.ResponseWriter, *http.Request), body any) T {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshaling request: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var out T
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+	return out
+}
+
+func TestHandleCreateRejectsUnsupportedOpts(t *testing.T) {
+	s := newTestServer(&fakeMountController{})
+
+	resp := call[errResponse](t, s.handleCreate, createRequest{Name: "vol1", Opts: map[string]string{"mount_options": "ro"}})
+
+	if resp.Err == "" {
+		t.Fatalf("expected error for unsupported opt")
+	}
+	if _, ok := s.volumes["vol1"]; ok {
+		t.Fatalf("volume should not have been created")
+	}
+}
+
+func TestHandleCreateAcceptsPathOpt(t *testing.T) {
+	s := newTestServer(&fakeMountController{})
+	s.stateFile = filepath.Join(t.TempDir(), "docker-volumes.json")
+
+	resp := call[errResponse](t, s.handleCreate, createRequest{Name: "vol1", Opts: map[string]string{"path": "movies"}})
+	if resp.Err != "" {
+		t.Fatalf("unexpected error: %s", resp.Err)
+	}
+
+	v, ok := s.volumes["vol1"]
+	if !ok {
+		t.Fatalf("expected volume to be registered")
+	}
+	if v.Subpath != "movies" {
+		t.Fatalf("Subpath = %q, want %q", v.Subpath, "movies")
+	}
+}
+
+func TestHandleMountStartsMountOnlyOnce(t *testing.T) {
+	mount := &fakeMountController{}
+	s := newTestServer(mount)
+	s.volumes["vol1"] = &volume{Name: "vol1", Mounts: make(map[string]struct{})}
+	s.volumes["vol2"] = &volume{Name: "vol2", Mounts: make(map[string]struct{})}
+
+	call[pathOrMountResponse](t, s.handleMount, mountRequest{Name: "vol1", ID: "container-a"})
+	call[pathOrMountResponse](t, s.handleMount, mountRequest{Name: "vol2", ID: "container-b"})
+
+	mount.mu.Lock()
+	defer mount.mu.Unlock()
+	if mount.mountCalls != 1 {
+		t.Fatalf("Mount called %d times, want 1", mount.mountCalls)
+	}
+	if s.mountCount != 2 {
+		t.Fatalf("mountCount = %d, want 2", s.mountCount)
+	}
+}
+
+func TestHandleMountIsIdempotentForSameContainerID(t *testing.T) {
+	mount := &fakeMountController{}
+	s := newTestServer(mount)
+	s.volumes["vol1"] = &volume{Name: "vol1", Mounts: make(map[string]struct{})}
+
+	call[pathOrMountResponse](t, s.handleMount, mountRequest{Name: "vol1", ID: "container-a"})
+	call[pathOrMountResponse](t, s.handleMount, mountRequest{Name: "vol1", ID: "container-a"})
+
+	mount.mu.Lock()
+	defer mount.mu.Unlock()
+	if mount.mountCalls != 1 {
+		t.Fatalf("Mount called %d times, want 1", mount.mountCalls)
+	}
+	if s.mountCount != 1 {
+		t.Fatalf("mountCount = %d, want 1 (repeated Mount for the same container must not double-count)", s.mountCount)
+	}
+}
+
+func TestHandleUnmountKeepsStateIfRealUnmountFails(t *testing.T) {
+	mount := &fakeMountController{unmountErr: errors.New("fuse busy")}
+	s := newTestServer(mount)
+	s.volumes["vol1"] = &volume{Name: "vol1", Mounts: map[string]struct{}{"container-a": {}}}
+	s.mountCount = 1
+
+	resp := call[errResponse](t, s.handleUnmount, unmountRequest{Name: "vol1", ID: "container-a"})
+
+	if resp.Err == "" {
+		t.Fatalf("expected error response when Unmount fails")
+	}
+	if s.mountCount != 1 {
+		t.Fatalf("mountCount = %d, want 1 (unchanged after failed unmount)", s.mountCount)
+	}
+	if _, held := s.volumes["vol1"].Mounts["container-a"]; !held {
+		t.Fatalf("expected volume to still be marked mounted by container-a after failed unmount")
+	}
+}
+
+func TestHandleUnmountClearsStateOnSuccess(t *testing.T) {
+	mount := &fakeMountController{}
+	s := newTestServer(mount)
+	s.volumes["vol1"] = &volume{Name: "vol1", Mounts: map[string]struct{}{"container-a": {}}}
+	s.mountCount = 1
+
+	resp := call[errResponse](t, s.handleUnmount, unmountRequest{Name: "vol1", ID: "container-a"})
+
+	if resp.Err != "" {
+		t.Fatalf("unexpected error: %s", resp.Err)
+	}
+	if s.mountCount != 0 {
+		t.Fatalf("mountCount = %d, want 0", s.mountCount)
+	}
+	if _, held := s.volumes["vol1"].Mounts["container-a"]; held {
+		t.Fatalf("expected container-a to be released")
+	}
+
+	mount.mu.Lock()
+	defer mount.mu.Unlock()
+	if mount.unmountCalls != 1 {
+		t.Fatalf("Unmount called %d times, want 1", mount.unmountCalls)
+	}
+}
+
+func TestHandleUnmountIsNoOpWhenNotHeldByContainer(t *testing.T) {
+	mount := &fakeMountController{}
+	s := newTestServer(mount)
+	s.volumes["vol1"] = &volume{Name: "vol1", Mounts: make(map[string]struct{})}
+
+	resp := call[errResponse](t, s.handleUnmount, unmountRequest{Name: "vol1", ID: "container-a"})
+	if resp.Err != "" {
+		t.Fatalf("unexpected error: %s", resp.Err)
+	}
+
+	mount.mu.Lock()
+	defer mount.mu.Unlock()
+	if mount.unmountCalls != 0 {
+		t.Fatalf("Unmount should not be called for an unheld volume")
+	}
+}
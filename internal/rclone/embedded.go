@@ -0,0 +1,101 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfsflags"
+)
+
+// embeddedMount owns an in-process rclone VFS and its FUSE attachment.
+// MountService drives it through the same Mount/Unmount surface it uses
+// for the external backend, so callers don't need to know which one is
+// active.
+type embeddedMount struct {
+	mu         sync.Mutex
+	vfs        *vfs.VFS
+	mountPoint string
+	unmount    func() error
+}
+
+// newEmbeddedMount constructs the VFS for f but does not mount it yet.
+func newEmbeddedMount(f fs.Fs, mountPoint string) *embeddedMount {
+	return &embeddedMount{
+		vfs:        vfs.New(f, &vfsflags.Opt),
+		mountPoint: mountPoint,
+	}
+}
+
+// Mount attaches the embedded VFS to its mountpoint via the platform mount
+// backend (cmd/mount on Linux/macOS, cmd/cmount on Windows). It is a no-op
+// if already mounted.
+func (e *embeddedMount) Mount(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.unmount != nil {
+		return nil
+	}
+
+	unmount, err := mountEmbedded(ctx, e.vfs, e.mountPoint)
+	if err != nil {
+		return fmt.Errorf("mounting embedded vfs at %s: %w", e.mountPoint, err)
+	}
+
+	e.unmount = unmount
+	return nil
+}
+
+// Unmount detaches the embedded VFS. It is a no-op if not mounted.
+func (e *embeddedMount) Unmount(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.unmount == nil {
+		return nil
+	}
+
+	if err := e.unmount(); err != nil {
+		return fmt.Errorf("unmounting embedded vfs at %s: %w", e.mountPoint, err)
+	}
+
+	e.unmount = nil
+	return nil
+}
+
+// Mounted reports whether the embedded VFS is currently attached.
+func (e *embeddedMount) Mounted() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unmount != nil
+}
+
+// Refresh forgets cached directory listings under dir so the next access
+// re-lists it from the remote. This replaces the "vfs/refresh" RC call used
+// by the external backend.
+func (e *embeddedMount) Refresh(ctx context.Context, dir string) error {
+	node, err := e.vfs.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("refreshing %s: %w", dir, err)
+	}
+	if d, ok := node.(*vfs.Dir); ok {
+		d.ForgetAll()
+	}
+	return nil
+}
+
+// Forget drops cached metadata under dir. This replaces the "vfs/forget" RC
+// call used by the external backend.
+func (e *embeddedMount) Forget(ctx context.Context, dir string) error {
+	return e.Refresh(ctx, dir)
+}
+
+// Invalidate drops the whole directory cache. This replaces the
+// "vfs/invalidate" RC call used by the external backend.
+func (e *embeddedMount) Invalidate(ctx context.Context, path string) error {
+	e.vfs.FlushDirCache()
+	return nil
+}
@@ -0,0 +1,35 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEmbeddedMountUnmountIsNoOpWhenNotMounted(t *testing.T) {
+	e := &embeddedMount{mountPoint: "/mnt/altmount"}
+
+	if e.Mounted() {
+		t.Fatalf("expected a freshly constructed embeddedMount to report unmounted")
+	}
+
+	// Unmount must not dereference e.unmount when it's nil; it should just
+	// return cleanly instead of invoking the real platform mount backend.
+	if err := e.Unmount(context.Background()); err != nil {
+		t.Fatalf("Unmount on an unmounted embeddedMount returned error: %v", err)
+	}
+}
+
+func TestEmbeddedMountMountedReflectsUnmountFunc(t *testing.T) {
+	e := &embeddedMount{mountPoint: "/mnt/altmount", unmount: func() error { return nil }}
+
+	if !e.Mounted() {
+		t.Fatalf("expected embeddedMount with a set unmount func to report mounted")
+	}
+
+	if err := e.Unmount(context.Background()); err != nil {
+		t.Fatalf("Unmount: %v", err)
+	}
+	if e.Mounted() {
+		t.Fatalf("expected Unmount to clear the mounted state")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package rclone
+
+import (
+	"context"
+
+	"github.com/rclone/rclone/cmd/cmount"
+	"github.com/rclone/rclone/vfs"
+)
+
+// mountEmbedded attaches VFS at mountPoint using rclone's cgofuse based
+// mount implementation, returning a function that unmounts it.
+func mountEmbedded(ctx context.Context, VFS *vfs.VFS, mountPoint string) (func() error, error) {
+	_, unmount, err := cmount.Mount(VFS, mountPoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return unmount, nil
+}
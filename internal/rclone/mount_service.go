@@ -0,0 +1,238 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/javi11/altmount/internal/config"
+	"github.com/javi11/altmount/internal/uploadqueue"
+	"github.com/javi11/altmount/pkg/rclonecli"
+	"github.com/rclone/rclone/fs"
+)
+
+// Status reports the current state of the mount, uniformly across both
+// backends.
+type Status struct {
+	Mounted   bool           `json:"mounted"`
+	MountPath string         `json:"mount_path"`
+	Backend   config.Backend `json:"backend"`
+	MountedAt time.Time      `json:"mounted_at,omitempty"`
+}
+
+// MountService owns the altmount FUSE mount, regardless of whether it is
+// backed by an external rclone RC server or an embedded, in-process VFS.
+type MountService struct {
+	configGetter config.ConfigGetter
+
+	mu        sync.Mutex
+	mounted   bool
+	mountedAt time.Time
+	remote    fs.Fs
+
+	embedded *embeddedMount
+	uploads  *OfflineUploadManager
+}
+
+// NewMountService creates a MountService. The backend used for each Mount
+// call is read from configGetter().RClone.Backend at mount time, so it can
+// change across reloads without recreating the service.
+func NewMountService(configGetter config.ConfigGetter) *MountService {
+	return &MountService{configGetter: configGetter}
+}
+
+// Mount attaches the configured remote at cfg.MountPath, using whichever
+// backend is configured, and starts the offline upload queue if enabled.
+// It is a no-op if already mounted.
+func (m *MountService) Mount(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.mounted {
+		return nil
+	}
+
+	cfg := m.configGetter()
+
+	remote, err := fs.NewFs(ctx, cfg.RClone.Remote)
+	if err != nil {
+		return fmt.Errorf("resolving remote %q: %w", cfg.RClone.Remote, err)
+	}
+
+	switch cfg.RClone.Backend {
+	case config.BackendEmbedded:
+		m.embedded = newEmbeddedMount(remote, cfg.MountPath)
+		if err := m.embedded.Mount(ctx); err != nil {
+			m.embedded = nil
+			return err
+		}
+	default:
+		if err := rclonecli.Mount(ctx, cfg.RClone.RCUrl, cfg.RClone.RCUser, cfg.RClone.RCPass, cfg.MountPath, cfg.RClone.MountOptions); err != nil {
+			return fmt.Errorf("mounting via external rclone RC server: %w", err)
+		}
+	}
+
+	m.remote = remote
+	m.mounted = true
+	m.mountedAt = time.Now()
+
+	if cfg.UploadQueue.Enabled {
+		uploads, err := m.startOfflineUploads(ctx, cfg, remote)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to start offline upload queue", "error", err)
+		} else {
+			m.uploads = uploads
+		}
+	}
+
+	return nil
+}
+
+// Unmount stops the offline upload queue (if running) and detaches the
+// mount. It is a no-op if not mounted.
+func (m *MountService) Unmount(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.mounted {
+		return nil
+	}
+
+	cfg := m.configGetter()
+
+	// Only release the offline upload queue once the real unmount below has
+	// succeeded; if it fails and we've already torn down m.uploads, the
+	// mount keeps running with nothing draining staged writes until a full
+	// unmount/remount cycle.
+	if m.embedded != nil {
+		if err := m.embedded.Unmount(ctx); err != nil {
+			return err
+		}
+		m.embedded = nil
+	} else {
+		if err := rclonecli.Unmount(ctx, cfg.RClone.RCUrl, cfg.RClone.RCUser, cfg.RClone.RCPass, cfg.MountPath); err != nil {
+			return fmt.Errorf("unmounting via external rclone RC server: %w", err)
+		}
+	}
+
+	if m.uploads != nil {
+		m.uploads.Stop()
+		m.uploads = nil
+	}
+
+	m.mounted = false
+	m.remote = nil
+	return nil
+}
+
+// Refresh forgets cached directory listings under dir so the next access
+// re-lists it from the remote. Only supported on the embedded backend; the
+// external backend has no equivalent RC call wired up yet.
+func (m *MountService) Refresh(ctx context.Context, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.embedded == nil {
+		return fmt.Errorf("cache refresh is only supported with the embedded backend")
+	}
+	return m.embedded.Refresh(ctx, dir)
+}
+
+// Forget drops cached metadata under dir. Only supported on the embedded
+// backend; the external backend has no equivalent RC call wired up yet.
+func (m *MountService) Forget(ctx context.Context, dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.embedded == nil {
+		return fmt.Errorf("cache forget is only supported with the embedded backend")
+	}
+	return m.embedded.Forget(ctx, dir)
+}
+
+// Invalidate drops the whole directory cache. Only supported on the
+// embedded backend; the external backend has no equivalent RC call wired up
+// yet.
+func (m *MountService) Invalidate(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.embedded == nil {
+		return fmt.Errorf("cache invalidate is only supported with the embedded backend")
+	}
+	return m.embedded.Invalidate(ctx, path)
+}
+
+// GetStatus reports whether the mount is active and which backend served
+// it.
+func (m *MountService) GetStatus() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := m.configGetter()
+	return Status{
+		Mounted:   m.mounted,
+		MountPath: cfg.MountPath,
+		Backend:   cfg.RClone.Backend,
+		MountedAt: m.mountedAt,
+	}
+}
+
+// PendingUploads returns how many items are queued, uploading or retrying
+// in the offline upload queue, or 0 if offline uploading is not enabled.
+func (m *MountService) PendingUploads() (int, error) {
+	queue, ok := m.Uploads()
+	if !ok {
+		return 0, nil
+	}
+	return queue.Pending()
+}
+
+// Uploads returns the offline upload queue and true if offline uploading is
+// currently enabled and running, or (nil, false) otherwise. Callers must
+// treat a false ok as "feature not enabled", not an error.
+func (m *MountService) Uploads() (*uploadqueue.Queue, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.uploads == nil {
+		return nil, false
+	}
+	return m.uploads.queue, true
+}
+
+// startOfflineUploads opens the persistent upload queue and worker pool for
+// the given remote, staging writes under cfg.UploadQueue.StagingDir (or a
+// "uploads" directory under cfg.DataDir by default).
+func (m *MountService) startOfflineUploads(ctx context.Context, cfg *config.Config, remote fs.Fs) (*OfflineUploadManager, error) {
+	stagingDir := cfg.UploadQueue.StagingDir
+	if stagingDir == "" {
+		stagingDir = filepath.Join(cfg.DataDir, "uploads")
+	}
+
+	dbPath := cfg.UploadQueue.DBPath
+	if dbPath == "" {
+		dbPath = filepath.Join(cfg.RClone.CacheDir, "uploads.db")
+	}
+
+	queue, err := uploadqueue.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening upload queue: %w", err)
+	}
+
+	workers := cfg.UploadQueue.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	manager := NewOfflineUploadManager(stagingDir, queue, &remoteUploader{remote: remote}, workers)
+	if err := manager.Start(ctx); err != nil {
+		_ = queue.Close()
+		return nil, err
+	}
+
+	return manager, nil
+}
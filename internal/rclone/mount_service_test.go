@@ -0,0 +1,22 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/javi11/altmount/internal/config"
+)
+
+func TestMountServiceRefreshRequiresEmbeddedBackend(t *testing.T) {
+	m := NewMountService(func() *config.Config { return &config.Config{} })
+
+	if err := m.Refresh(context.Background(), "/"); err == nil {
+		t.Fatalf("expected Refresh to fail without an active embedded backend")
+	}
+	if err := m.Forget(context.Background(), "/"); err == nil {
+		t.Fatalf("expected Forget to fail without an active embedded backend")
+	}
+	if err := m.Invalidate(context.Background(), "/"); err == nil {
+		t.Fatalf("expected Invalidate to fail without an active embedded backend")
+	}
+}
@@ -0,0 +1,194 @@
+package rclone
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	rclonefs "github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+
+	"github.com/javi11/altmount/internal/uploadqueue"
+)
+
+// quietPeriod is how long a staged file must go unmodified before the
+// poller assumes the write that created it has finished, so it doesn't
+// enqueue a file that's still being written to.
+const quietPeriod = 2 * time.Second
+
+// pollInterval is how often the staging directory is scanned for newly
+// completed writes.
+const pollInterval = 5 * time.Second
+
+// OfflineUploadManager watches a local staging directory for files written
+// through the FUSE mount and feeds them into the persistent write-back
+// upload queue, then drains that queue with a worker pool.
+type OfflineUploadManager struct {
+	stagingDir string
+	queue      *uploadqueue.Queue
+	pool       *uploadqueue.WorkerPool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewOfflineUploadManager creates a manager that stages writes under
+// stagingDir, persists them in queue, and uploads them via uploader using
+// workers concurrent workers.
+func NewOfflineUploadManager(stagingDir string, queue *uploadqueue.Queue, uploader uploadqueue.Uploader, workers int) *OfflineUploadManager {
+	return &OfflineUploadManager{
+		stagingDir: stagingDir,
+		queue:      queue,
+		pool:       uploadqueue.NewWorkerPool(queue, uploader, workers),
+	}
+}
+
+// Start creates the staging directory, begins polling it for completed
+// writes, and starts the upload worker pool.
+func (m *OfflineUploadManager) Start(ctx context.Context) error {
+	if err := os.MkdirAll(m.stagingDir, 0755); err != nil {
+		return fmt.Errorf("creating upload staging dir %s: %w", m.stagingDir, err)
+	}
+
+	pollCtx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go m.pollLoop(pollCtx)
+
+	m.pool.Start(ctx)
+	return nil
+}
+
+// Stop halts the staging-directory poller and the upload worker pool, then
+// closes the queue database.
+func (m *OfflineUploadManager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+	m.pool.Stop()
+	if err := m.queue.Close(); err != nil {
+		slog.Error("Failed to close upload queue", "error", err)
+	}
+}
+
+func (m *OfflineUploadManager) pollLoop(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.pollOnce(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to scan upload staging dir", "dir", m.stagingDir, "error", err)
+			}
+		}
+	}
+}
+
+// pollOnce walks the staging directory and enqueues any file that is new
+// and has not been modified in the last quietPeriod, i.e. the write that
+// created it has settled.
+//
+// Whether a path is "new" is decided by looking up its queue entry (keyed
+// by virtual path) directly rather than an in-memory set: a set entry would
+// never be cleared once written, so a virtual path re-staged after its
+// first upload completed and its staged file was removed would be silently
+// skipped forever.
+func (m *OfflineUploadManager) pollOnce(ctx context.Context) error {
+	now := time.Now()
+
+	return filepath.WalkDir(m.stagingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		virtualPath, err := filepath.Rel(m.stagingDir, path)
+		if err != nil {
+			return err
+		}
+
+		if existing, err := m.queue.Get(virtualPath); err == nil && existing.State != uploadqueue.StateUploaded {
+			// Already tracked and not yet finished; nothing to do until it
+			// reaches a terminal state.
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if now.Sub(info.ModTime()) < quietPeriod {
+			return nil
+		}
+
+		sum, err := sha1Sum(path)
+		if err != nil {
+			return fmt.Errorf("hashing %s: %w", path, err)
+		}
+
+		if _, err := m.queue.Enqueue(virtualPath, path, virtualPath, info.Size(), sum); err != nil {
+			return fmt.Errorf("enqueueing %s: %w", path, err)
+		}
+
+		slog.InfoContext(ctx, "Queued staged write for upload", "path", virtualPath, "size", info.Size())
+		return nil
+	})
+}
+
+func sha1Sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remoteUploader uploads staged local files to the mount's backing rclone
+// remote and verifies them by stat-ing the result.
+type remoteUploader struct {
+	remote rclonefs.Fs
+}
+
+// Upload copies the staged file at localPath to virtualPath on the remote.
+func (u *remoteUploader) Upload(ctx context.Context, localPath, virtualPath string) error {
+	localFs, err := rclonefs.NewFs(ctx, filepath.Dir(localPath))
+	if err != nil {
+		return fmt.Errorf("opening local staging dir %s: %w", filepath.Dir(localPath), err)
+	}
+
+	return operations.CopyFile(ctx, u.remote, localFs, virtualPath, filepath.Base(localPath))
+}
+
+// Stat confirms virtualPath is visible on the remote and returns its size.
+func (u *remoteUploader) Stat(ctx context.Context, virtualPath string) (int64, error) {
+	obj, err := u.remote.NewObject(ctx, virtualPath)
+	if err != nil {
+		return 0, err
+	}
+	return obj.Size(), nil
+}
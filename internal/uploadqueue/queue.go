@@ -0,0 +1,229 @@
+// Package uploadqueue implements a persistent write-back queue for files
+// written into the FUSE mount while offline uploading is enabled. Writes
+// land on local disk immediately and are drained to the configured
+// Usenet/NZB backend asynchronously, so the mount stays writable even when
+// the network is down.
+package uploadqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// State is the lifecycle stage of a queued upload.
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateUploading State = "uploading"
+	StateUploaded  State = "uploaded"
+	StateFailed    State = "failed"
+	StateCleaning  State = "cleaning"
+)
+
+var itemsBucket = []byte("uploads")
+
+// Item is a single staged file waiting to be uploaded.
+type Item struct {
+	ID          string    `json:"id"`
+	LocalPath   string    `json:"local_path"`
+	VirtualPath string    `json:"virtual_path"`
+	Size        int64     `json:"size"`
+	SHA1        string    `json:"sha1"`
+	Attempts    int       `json:"attempts"`
+	NextRetry   time.Time `json:"next_retry"`
+	State       State     `json:"state"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Queue persists upload items in a BoltDB file under the cache dir so the
+// write-back backlog survives a daemon restart.
+type Queue struct {
+	db *bbolt.DB
+}
+
+// Open creates or opens the queue database at path.
+func Open(path string) (*Queue, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening upload queue %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(itemsBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("initializing upload queue %s: %w", path, err)
+	}
+
+	return &Queue{db: db}, nil
+}
+
+// Close releases the underlying database file.
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+// Enqueue adds a new item in StatePending and returns it.
+func (q *Queue) Enqueue(id, localPath, virtualPath string, size int64, sha1 string) (*Item, error) {
+	now := time.Now()
+	item := &Item{
+		ID:          id,
+		LocalPath:   localPath,
+		VirtualPath: virtualPath,
+		Size:        size,
+		SHA1:        sha1,
+		State:       StatePending,
+		NextRetry:   now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := q.put(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Get returns a single item by ID.
+func (q *Queue) Get(id string) (*Item, error) {
+	var item *Item
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(itemsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("upload %q not found", id)
+		}
+		item = &Item{}
+		return json.Unmarshal(data, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListFilter narrows a List call.
+type ListFilter struct {
+	State  State // empty matches any state
+	Offset int
+	Limit  int // 0 means no limit
+}
+
+// List returns items matching filter, ordered by CreatedAt, along with the
+// total number of items matching filter (ignoring Offset/Limit) for
+// pagination.
+func (q *Queue) List(filter ListFilter) ([]*Item, int, error) {
+	var matched []*Item
+
+	err := q.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(itemsBucket).ForEach(func(_, data []byte) error {
+			var item Item
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			if filter.State == "" || item.State == filter.State {
+				matched = append(matched, &item)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.Before(matched[j].CreatedAt) })
+
+	total := len(matched)
+	start := filter.Offset
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+
+	return matched[start:end], total, nil
+}
+
+// UpdateState transitions item id to state, recording lastErr if non-nil.
+func (q *Queue) UpdateState(id string, state State, lastErr error) error {
+	item, err := q.Get(id)
+	if err != nil {
+		return err
+	}
+
+	item.State = state
+	item.UpdatedAt = time.Now()
+	if lastErr != nil {
+		item.LastError = lastErr.Error()
+	}
+
+	return q.put(item)
+}
+
+// Retry resets a failed item back to StatePending for immediate re-attempt.
+func (q *Queue) Retry(id string) (*Item, error) {
+	item, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	item.State = StatePending
+	item.NextRetry = time.Now()
+	item.LastError = ""
+	item.UpdatedAt = time.Now()
+
+	if err := q.put(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// MarkForRetry records a failed upload attempt: it increments Attempts,
+// moves item to StateFailed and schedules the next attempt backoff from now.
+func (q *Queue) MarkForRetry(id string, uploadErr error, backoff time.Duration) error {
+	item, err := q.Get(id)
+	if err != nil {
+		return err
+	}
+
+	item.Attempts++
+	item.State = StateFailed
+	item.NextRetry = time.Now().Add(backoff)
+	item.LastError = uploadErr.Error()
+	item.UpdatedAt = time.Now()
+
+	return q.put(item)
+}
+
+// Delete removes an item from the queue. Callers are responsible for
+// removing the staged file first (typically after moving it through
+// StateCleaning).
+func (q *Queue) Delete(id string) error {
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(itemsBucket).Delete([]byte(id))
+	})
+}
+
+func (q *Queue) put(item *Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("encoding upload %q: %w", item.ID, err)
+	}
+
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(itemsBucket).Put([]byte(item.ID), data)
+	})
+}
@@ -0,0 +1,218 @@
+package uploadqueue
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestQueue(t *testing.T) *Queue {
+	t.Helper()
+
+	q, err := Open(filepath.Join(t.TempDir(), "uploads.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+func TestQueueEnqueueAndGet(t *testing.T) {
+	q := openTestQueue(t)
+
+	item, err := q.Enqueue("id-1", "/staging/a", "/a", 123, "deadbeef")
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if item.State != StatePending {
+		t.Fatalf("state = %q, want %q", item.State, StatePending)
+	}
+
+	got, err := q.Get("id-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.VirtualPath != "/a" || got.Size != 123 || got.SHA1 != "deadbeef" {
+		t.Fatalf("Get returned unexpected item: %+v", got)
+	}
+}
+
+func TestQueueUpdateStateRecordsError(t *testing.T) {
+	q := openTestQueue(t)
+
+	if _, err := q.Enqueue("id-1", "/staging/a", "/a", 1, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if err := q.UpdateState("id-1", StateFailed, errors.New("boom")); err != nil {
+		t.Fatalf("UpdateState: %v", err)
+	}
+
+	item, err := q.Get("id-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if item.State != StateFailed {
+		t.Fatalf("state = %q, want %q", item.State, StateFailed)
+	}
+	if item.LastError != "boom" {
+		t.Fatalf("LastError = %q, want %q", item.LastError, "boom")
+	}
+}
+
+func TestQueueMarkForRetryIncrementsAttempts(t *testing.T) {
+	q := openTestQueue(t)
+
+	if _, err := q.Enqueue("id-1", "/staging/a", "/a", 1, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	if err := q.MarkForRetry("id-1", errors.New("network down"), time.Minute); err != nil {
+		t.Fatalf("MarkForRetry: %v", err)
+	}
+
+	item, err := q.Get("id-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if item.Attempts != 1 {
+		t.Fatalf("Attempts = %d, want 1", item.Attempts)
+	}
+	if item.State != StateFailed {
+		t.Fatalf("state = %q, want %q", item.State, StateFailed)
+	}
+	if item.NextRetry.Before(time.Now().Add(59 * time.Second)) {
+		t.Fatalf("NextRetry = %v, expected roughly 1 minute out", item.NextRetry)
+	}
+}
+
+func TestQueueRetryResetsToPending(t *testing.T) {
+	q := openTestQueue(t)
+
+	if _, err := q.Enqueue("id-1", "/staging/a", "/a", 1, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.MarkForRetry("id-1", errors.New("boom"), time.Hour); err != nil {
+		t.Fatalf("MarkForRetry: %v", err)
+	}
+
+	item, err := q.Retry("id-1")
+	if err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if item.State != StatePending {
+		t.Fatalf("state = %q, want %q", item.State, StatePending)
+	}
+	if item.LastError != "" {
+		t.Fatalf("LastError = %q, want empty", item.LastError)
+	}
+	if item.NextRetry.After(time.Now()) {
+		t.Fatalf("NextRetry = %v, expected immediate retry", item.NextRetry)
+	}
+}
+
+func TestQueueListFiltersAndPaginates(t *testing.T) {
+	q := openTestQueue(t)
+
+	for i := 0; i < 5; i++ {
+		id := string(rune('a' + i))
+		if _, err := q.Enqueue(id, "/staging/"+id, "/"+id, 1, "sum"); err != nil {
+			t.Fatalf("Enqueue %s: %v", id, err)
+		}
+	}
+	if err := q.UpdateState("a", StateUploaded, nil); err != nil {
+		t.Fatalf("UpdateState: %v", err)
+	}
+
+	pending, total, err := q.List(ListFilter{State: StatePending})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 4 {
+		t.Fatalf("total = %d, want 4", total)
+	}
+	if len(pending) != 4 {
+		t.Fatalf("len(pending) = %d, want 4", len(pending))
+	}
+
+	page, total, err := q.List(ListFilter{State: StatePending, Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("List with pagination: %v", err)
+	}
+	if total != 4 {
+		t.Fatalf("total = %d, want 4", total)
+	}
+	if len(page) != 2 {
+		t.Fatalf("len(page) = %d, want 2", len(page))
+	}
+}
+
+func TestQueueListClampsNegativeOffset(t *testing.T) {
+	q := openTestQueue(t)
+
+	for i := 0; i < 3; i++ {
+		id := string(rune('a' + i))
+		if _, err := q.Enqueue(id, "/staging/"+id, "/"+id, 1, "sum"); err != nil {
+			t.Fatalf("Enqueue %s: %v", id, err)
+		}
+	}
+
+	page, total, err := q.List(ListFilter{Offset: -1, Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("total = %d, want 3", total)
+	}
+	if len(page) != 2 {
+		t.Fatalf("len(page) = %d, want 2", len(page))
+	}
+}
+
+func TestQueueDeleteRemovesItem(t *testing.T) {
+	q := openTestQueue(t)
+
+	if _, err := q.Enqueue("id-1", "/staging/a", "/a", 1, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.Delete("id-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := q.Get("id-1"); err == nil {
+		t.Fatalf("expected Get to fail after Delete")
+	}
+}
+
+func TestQueueStatsAndPending(t *testing.T) {
+	q := openTestQueue(t)
+
+	if _, err := q.Enqueue("pending-1", "/staging/a", "/a", 100, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := q.Enqueue("failed-1", "/staging/b", "/b", 50, "sum"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := q.UpdateState("failed-1", StateFailed, errors.New("boom")); err != nil {
+		t.Fatalf("UpdateState: %v", err)
+	}
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.PendingCount != 1 || stats.PendingBytes != 100 {
+		t.Fatalf("unexpected pending stats: %+v", stats)
+	}
+	if stats.FailedCount != 1 {
+		t.Fatalf("FailedCount = %d, want 1", stats.FailedCount)
+	}
+
+	pending, err := q.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if pending != 2 {
+		t.Fatalf("Pending = %d, want 2", pending)
+	}
+}
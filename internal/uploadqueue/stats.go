@@ -0,0 +1,58 @@
+package uploadqueue
+
+import "time"
+
+// Stats summarizes the current backlog, for the mount status endpoint and
+// the dedicated uploads stats endpoint.
+type Stats struct {
+	PendingCount     int     `json:"pending_count"`
+	PendingBytes     int64   `json:"pending_bytes"`
+	UploadingCount   int     `json:"uploading_count"`
+	FailedCount      int     `json:"failed_count"`
+	OldestPendingAge float64 `json:"oldest_pending_age_seconds"`
+}
+
+// Stats scans the queue and computes a Stats summary.
+func (q *Queue) Stats() (Stats, error) {
+	var stats Stats
+	var oldest time.Time
+
+	for _, state := range []State{StatePending, StateUploading, StateFailed} {
+		items, _, err := q.List(ListFilter{State: state})
+		if err != nil {
+			return Stats{}, err
+		}
+
+		for _, item := range items {
+			switch state {
+			case StatePending:
+				stats.PendingCount++
+				stats.PendingBytes += item.Size
+				if oldest.IsZero() || item.CreatedAt.Before(oldest) {
+					oldest = item.CreatedAt
+				}
+			case StateUploading:
+				stats.UploadingCount++
+			case StateFailed:
+				stats.FailedCount++
+			}
+		}
+	}
+
+	if !oldest.IsZero() {
+		stats.OldestPendingAge = time.Since(oldest).Seconds()
+	}
+
+	return stats, nil
+}
+
+// Pending returns the size of the backlog (pending, uploading and
+// failed-and-retrying items), which is what StopMount checks before refusing
+// to unmount.
+func (q *Queue) Pending() (int, error) {
+	stats, err := q.Stats()
+	if err != nil {
+		return 0, err
+	}
+	return stats.PendingCount + stats.UploadingCount + stats.FailedCount, nil
+}
@@ -0,0 +1,179 @@
+package uploadqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Uploader pushes a staged local file to its virtual destination and can
+// verify it landed correctly. Implementations wrap altmount's Usenet/NZB
+// posting path.
+type Uploader interface {
+	Upload(ctx context.Context, localPath, virtualPath string) error
+	// Stat confirms the upload is visible on the remote and returns its
+	// size, used to verify before the staged copy is removed.
+	Stat(ctx context.Context, virtualPath string) (size int64, err error)
+}
+
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 30 * time.Minute
+	maxAttempts = 10
+)
+
+// WorkerPool drains a Queue, uploading pending items with exponential
+// backoff on failure, and only removes staged files once the upload is
+// verified on the remote.
+type WorkerPool struct {
+	queue    *Queue
+	uploader Uploader
+	workers  int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWorkerPool creates a pool that drains queue using uploader across
+// workers concurrent goroutines.
+func NewWorkerPool(queue *Queue, uploader Uploader, workers int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &WorkerPool{queue: queue, uploader: uploader, workers: workers}
+}
+
+// Start launches the worker goroutines. Stop must be called to release
+// them.
+func (p *WorkerPool) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.drainOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop signals the worker pool to exit and waits for it to finish the
+// current pass.
+func (p *WorkerPool) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// drainOnce uploads every item that is due for an attempt right now, running
+// up to p.workers attempts concurrently.
+func (p *WorkerPool) drainOnce(ctx context.Context) {
+	items, _, err := p.queue.List(ListFilter{State: StatePending})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list pending uploads", "error", err)
+		return
+	}
+
+	failed, _, err := p.queue.List(ListFilter{State: StateFailed})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list failed uploads", "error", err)
+		return
+	}
+	items = append(items, failed...)
+
+	now := time.Now()
+	sem := make(chan struct{}, p.workers)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		if item.NextRetry.After(now) {
+			continue
+		}
+
+		item := item
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.attempt(ctx, item)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *WorkerPool) attempt(ctx context.Context, item *Item) {
+	if err := p.queue.UpdateState(item.ID, StateUploading, nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark upload in progress", "id", item.ID, "error", err)
+		return
+	}
+
+	if err := p.uploader.Upload(ctx, item.LocalPath, item.VirtualPath); err != nil {
+		p.fail(ctx, item, fmt.Errorf("uploading: %w", err))
+		return
+	}
+
+	remoteSize, err := p.uploader.Stat(ctx, item.VirtualPath)
+	if err != nil {
+		p.fail(ctx, item, fmt.Errorf("verifying upload: %w", err))
+		return
+	}
+	if remoteSize != item.Size {
+		p.fail(ctx, item, fmt.Errorf("verifying upload: remote size %d does not match staged size %d", remoteSize, item.Size))
+		return
+	}
+
+	if err := p.queue.UpdateState(item.ID, StateCleaning, nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark upload cleaning", "id", item.ID, "error", err)
+		return
+	}
+
+	if err := os.Remove(item.LocalPath); err != nil && !os.IsNotExist(err) {
+		slog.ErrorContext(ctx, "Uploaded but failed to remove staged file", "id", item.ID, "path", item.LocalPath, "error", err)
+	}
+
+	if err := p.queue.UpdateState(item.ID, StateUploaded, nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark upload complete", "id", item.ID, "error", err)
+	}
+}
+
+func (p *WorkerPool) fail(ctx context.Context, item *Item, err error) {
+	slog.WarnContext(ctx, "Upload attempt failed", "id", item.ID, "attempt", item.Attempts+1, "error", err)
+
+	if item.Attempts+1 >= maxAttempts {
+		if updateErr := p.queue.UpdateState(item.ID, StateFailed, err); updateErr != nil {
+			slog.ErrorContext(ctx, "Failed to record permanently failed upload", "id", item.ID, "error", updateErr)
+		}
+		return
+	}
+
+	if markErr := p.queue.MarkForRetry(item.ID, err, backoffFor(item.Attempts)); markErr != nil {
+		slog.ErrorContext(ctx, "Failed to schedule upload retry", "id", item.ID, "error", markErr)
+	}
+}
+
+// backoffFor returns the exponential backoff delay before retry attempt
+// number attempts+1, capped at maxBackoff.
+func backoffFor(attempts int) time.Duration {
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempts)))
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
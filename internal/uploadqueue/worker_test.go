@@ -0,0 +1,36 @@
+package uploadqueue
+
+import "testing"
+
+func TestBackoffForDoublesUntilCap(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     string
+	}{
+		{0, "30s"},
+		{1, "1m0s"},
+		{2, "2m0s"},
+		{3, "4m0s"},
+	}
+
+	for _, tc := range cases {
+		if got := backoffFor(tc.attempts); got.String() != tc.want {
+			t.Errorf("backoffFor(%d) = %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestBackoffForCapsAtMaxBackoff(t *testing.T) {
+	if got := backoffFor(20); got != maxBackoff {
+		t.Errorf("backoffFor(20) = %s, want cap %s", got, maxBackoff)
+	}
+}
+
+func TestNewWorkerPoolDefaultsWorkerCount(t *testing.T) {
+	q := openTestQueue(t)
+
+	p := NewWorkerPool(q, nil, 0)
+	if p.workers != 1 {
+		t.Errorf("workers = %d, want 1", p.workers)
+	}
+}